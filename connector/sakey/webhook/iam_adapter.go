@@ -0,0 +1,18 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+package webhook
+
+import "context"
+
+// IamAdapter resolves and authorizes service accounts against Yandex IAM.
+// It is a thin slice of the SDK-backed adapter used by the reconcilers,
+// kept separate so the validator can be tested against a fake.
+type IamAdapter interface {
+	// ServiceAccountExists reports whether serviceAccountID names a real
+	// service account in the cloud.
+	ServiceAccountExists(ctx context.Context, serviceAccountID string) (bool, error)
+	// HasPermission reports whether the caller holds permission on the
+	// given service account, e.g. "iam.serviceAccounts.use".
+	HasPermission(ctx context.Context, serviceAccountID, permission string) (bool, error)
+}
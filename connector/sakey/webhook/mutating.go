@@ -0,0 +1,62 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "k8s-connectors/connector/sakey/api/v1"
+	"k8s-connectors/pkg/webhook"
+)
+
+// defaultServiceAccountIDAnnotation lets a namespace pin the service account
+// that StaticAccessKeys created within it should bind to when the spec
+// does not name one explicitly.
+const defaultServiceAccountIDAnnotation = "connectors.cloud.yandex.com/default-service-account-id"
+
+// +kubebuilder:webhook:path=/mutate-connectors-cloud-yandex-com-v1-staticaccesskey,mutating=true,failurePolicy=fail,sideEffects=None,groups=connectors.cloud.yandex.com,resources=staticaccesskeys,verbs=create;update,versions=v1,name=mstaticaccesskey.yandex.com,admissionReviewVersions=v1
+
+type SAKeyMutator struct {
+	client.Client
+}
+
+func (r SAKeyMutator) Default(ctx context.Context, log logr.Logger, obj runtime.Object) error {
+	castedObj, ok := obj.(*v1.StaticAccessKey)
+	if !ok {
+		return fmt.Errorf("object is not of the StaticAccessKey type")
+	}
+
+	if castedObj.Spec.ServiceAccountID != "" {
+		return nil
+	}
+
+	var namespace corev1.Namespace
+	if err := r.Get(ctx, client.ObjectKey{Name: castedObj.Namespace}, &namespace); err != nil {
+		return fmt.Errorf("unable to resolve default service account: %v", err)
+	}
+
+	defaultServiceAccountID, ok := namespace.Annotations[defaultServiceAccountIDAnnotation]
+	if !ok {
+		// No default configured for this namespace, nothing to default
+		return nil
+	}
+
+	log.Info(
+		"defaulting empty serviceAccountID from namespace annotation",
+		"name", castedObj.Name, "serviceAccountID", defaultServiceAccountID,
+	)
+	castedObj.Spec.ServiceAccountID = defaultServiceAccountID
+
+	return webhook.NewFieldValidationWarning(
+		"spec.serviceAccountID",
+		defaultServiceAccountID,
+		fmt.Sprintf("defaulted from namespace annotation %s", defaultServiceAccountIDAnnotation),
+	)
+}
@@ -8,27 +8,50 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	v1 "k8s-connectors/connector/sakey/api/v1"
 	"k8s-connectors/pkg/webhook"
 )
 
+// iamServiceAccountUsePermission is required of the caller on the bound
+// service account; without it the key could never be used to authenticate.
+const iamServiceAccountUsePermission = "iam.serviceAccounts.use"
+
+// serviceAccountListGVK is the in-cluster CR that projects a Yandex IAM
+// service account into the cluster; StaticAccessKey.Spec.ServiceAccountID
+// is expected to name one of these by its status.id, not just a real IAM
+// service account. Listed as unstructured objects through the embedded
+// controller-runtime client so this package does
+// not need a compile-time dependency on that connector's api/v1 package.
+var serviceAccountListGVK = schema.GroupVersionKind{
+	Group:   "connectors.cloud.yandex.com",
+	Version: "v1",
+	Kind:    "ServiceAccountList",
+}
+
 // +kubebuilder:webhook:path=/validate-connectors-cloud-yandex-com-v1-staticaccesskey,mutating=false,failurePolicy=fail,sideEffects=None,groups=connectors.cloud.yandex.com,resources=staticaccesskeys,verbs=create;update;delete,versions=v1,name=vstaticaccesskey.yandex.com,admissionReviewVersions=v1
 
-type SAKeyValidator struct{}
+type SAKeyValidator struct {
+	client.Client
+	IamAdapter IamAdapter
+}
 
-func (r SAKeyValidator) ValidateCreation(_ context.Context, log logr.Logger, obj runtime.Object) error {
+func (r SAKeyValidator) ValidateCreation(ctx context.Context, log logr.Logger, obj runtime.Object) error {
 	castedObj, ok := obj.(*v1.StaticAccessKey)
 	if !ok {
 		return fmt.Errorf("object is not of the StaticAccessKey type")
 	}
 
 	log.Info("validate create", "name", castedObj.Name)
-	return nil
+
+	return r.validateServiceAccountID(ctx, castedObj.Spec.ServiceAccountID)
 }
 
-func (r SAKeyValidator) ValidateUpdate(_ context.Context, log logr.Logger, current, old runtime.Object) error {
+func (r SAKeyValidator) ValidateUpdate(ctx context.Context, log logr.Logger, current, old runtime.Object) error {
 	castedCurrent, ok := current.(*v1.StaticAccessKey)
 	if !ok {
 		return fmt.Errorf("object is not of the StaticAccessKey type")
@@ -42,8 +65,10 @@ func (r SAKeyValidator) ValidateUpdate(_ context.Context, log logr.Logger, curre
 	log.Info("validate update", "name", castedCurrent.Name)
 
 	if castedCurrent.Spec.ServiceAccountID != castedOld.Spec.ServiceAccountID {
-		return webhook.NewValidationError(
-			fmt.Errorf(
+		return webhook.NewFieldValidationError(
+			"spec.serviceAccountID",
+			castedCurrent.Spec.ServiceAccountID,
+			fmt.Sprintf(
 				"binded service account must be immutable, was changed from %s to %s",
 				castedOld.Spec.ServiceAccountID,
 				castedCurrent.Spec.ServiceAccountID,
@@ -51,7 +76,67 @@ func (r SAKeyValidator) ValidateUpdate(_ context.Context, log logr.Logger, curre
 		)
 	}
 
-	return nil
+	return r.validateServiceAccountID(ctx, castedCurrent.Spec.ServiceAccountID)
+}
+
+// validateServiceAccountID resolves serviceAccountID against the Yandex IAM
+// API and against the in-cluster ServiceAccount CRs, rejecting references
+// to a service account that is missing from either place (it must exist in
+// both) or that the caller is not allowed to use. This surfaces a typo in
+// Spec.ServiceAccountID at admission time instead of through an
+// asynchronous reconciler error.
+func (r SAKeyValidator) validateServiceAccountID(ctx context.Context, serviceAccountID string) error {
+	exists, err := r.IamAdapter.ServiceAccountExists(ctx, serviceAccountID)
+	if err != nil {
+		return fmt.Errorf("unable to resolve service account %s: %v", serviceAccountID, err)
+	}
+	if !exists {
+		return webhook.NewValidationError(
+			fmt.Errorf("service account %s does not exist", serviceAccountID),
+		)
+	}
+
+	allowed, err := r.IamAdapter.HasPermission(ctx, serviceAccountID, iamServiceAccountUsePermission)
+	if err != nil {
+		return fmt.Errorf("unable to check permissions on service account %s: %v", serviceAccountID, err)
+	}
+	if !allowed {
+		return webhook.NewValidationError(
+			fmt.Errorf(
+				"missing %s permission on service account %s",
+				iamServiceAccountUsePermission, serviceAccountID,
+			),
+		)
+	}
+
+	return r.validateServiceAccountCR(ctx, serviceAccountID)
+}
+
+// validateServiceAccountCR rejects serviceAccountID if no in-cluster
+// ServiceAccount resource reports it as its status.id - i.e. the IAM
+// service account exists, but nothing in the cluster is tracking it.
+func (r SAKeyValidator) validateServiceAccountCR(ctx context.Context, serviceAccountID string) error {
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(serviceAccountListGVK)
+	if err := r.List(ctx, &list); err != nil {
+		return fmt.Errorf("unable to list in-cluster ServiceAccount resources: %v", err)
+	}
+
+	for _, item := range list.Items {
+		id, found, err := unstructured.NestedString(item.Object, "status", "id")
+		if err != nil {
+			return fmt.Errorf(
+				"unable to read status.id of ServiceAccount %s/%s: %v", item.GetNamespace(), item.GetName(), err,
+			)
+		}
+		if found && id == serviceAccountID {
+			return nil
+		}
+	}
+
+	return webhook.NewValidationError(
+		fmt.Errorf("no in-cluster ServiceAccount resource tracks service account %s", serviceAccountID),
+	)
 }
 
 func (r SAKeyValidator) ValidateDeletion(_ context.Context, log logr.Logger, obj runtime.Object) error {
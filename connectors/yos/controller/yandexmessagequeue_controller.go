@@ -5,7 +5,6 @@ package controller
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-logr/logr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -17,19 +16,21 @@ import (
 	"k8s-connectors/connectors/yos/controller/phase"
 	yosconfig "k8s-connectors/connectors/yos/pkg/config"
 	"k8s-connectors/pkg/config"
+	genericphase "k8s-connectors/pkg/phase"
 	"k8s-connectors/pkg/util"
 )
 
+// maxPhaseConflictRetries bounds how many times a phase update is retried
+// against a freshly-fetched object after a ResourceVersion conflict.
+const maxPhaseConflictRetries = 3
+
 // yandexObjectStorageReconciler reconciles a YandexContainerRegistry object
 type yandexObjectStorageReconciler struct {
 	client.Client
 	log logr.Logger
-	// phases that are to be invoked on this object
-	// IsUpdated blocks Update, and order of initializers matters,
-	// thus if one of initializers fails, subsequent won't be processed.
-	// Upon destruction of object, phase cleanups are called in
-	// reverse order.
-	phases []phase.YandexObjectStoragePhase
+	// graph orders and runs this connector's phases by their declared
+	// dependencies rather than by slice position.
+	graph *genericphase.Graph
 }
 
 func NewYandexObjectStorageReconciler(
@@ -39,18 +40,24 @@ func NewYandexObjectStorageReconciler(
 	if err != nil {
 		return nil, err
 	}
+
+	graph, err := genericphase.NewGraph([]genericphase.Phase{
+		&phase.FinalizerRegistrar{
+			Client: cl,
+		},
+		&phase.ResourceAllocator{
+			Client: cl,
+			Sdk:    sdk,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &yandexObjectStorageReconciler{
 		Client: cl,
 		log:    log,
-		phases: []phase.YandexObjectStoragePhase{
-			&phase.FinalizerRegistrar{
-				Client: cl,
-			},
-			&phase.ResourceAllocator{
-				Client: cl,
-				Sdk:    sdk,
-			},
-		},
+		graph:  graph,
 	}, nil
 }
 
@@ -90,18 +97,15 @@ func (r *yandexObjectStorageReconciler) Reconcile(ctx context.Context, req ctrl.
 		return config.GetNormalResult()
 	}
 
-	// Update all fragments of object, keeping track of whether
-	// all of them are initialized
-	for _, updater := range r.phases {
-		isInitialized, err := updater.IsUpdated(ctx, &resource)
-		if err != nil {
-			return config.GetErroredResult(err)
-		}
-		if !isInitialized {
-			if err := updater.Update(ctx, log, &resource); err != nil {
-				return config.GetErroredResult(err)
-			}
-		}
+	// Run every phase that is not yet updated, in dependency order, running
+	// independent phases concurrently. A phase whose Update conflicts with a
+	// concurrent status patch is retried on its own, against a
+	// freshly-fetched object, instead of bubbling up and forcing a full
+	// requeue of every phase.
+	if err := r.graph.Update(ctx, log, &resource, func() error {
+		return r.Get(ctx, req.NamespacedName, &resource)
+	}, maxPhaseConflictRetries); err != nil {
+		return config.GetErroredResult(err)
 	}
 
 	return config.GetNormalResult()
@@ -116,10 +120,8 @@ func (r *yandexObjectStorageReconciler) mustBeFinalized(registry *connectorsv1.Y
 func (r *yandexObjectStorageReconciler) finalize(
 	ctx context.Context, log logr.Logger, registry *connectorsv1.YandexObjectStorage,
 ) error {
-	for i := len(r.phases); i != 0; i-- {
-		if err := r.phases[i-1].Cleanup(ctx, log, registry); err != nil {
-			return fmt.Errorf("error during finalization: %v", err)
-		}
+	if err := r.graph.Cleanup(ctx, log, registry); err != nil {
+		return err
 	}
 	log.Info("resource finalized successfully")
 	return nil
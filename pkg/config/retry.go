@@ -0,0 +1,53 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+package config
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// retryOnConflictBaseBackoff is the delay before the first retry; it
+// doubles on each subsequent attempt.
+const retryOnConflictBaseBackoff = 100 * time.Millisecond
+
+// RetryOnConflict mirrors the "retry on any error when working with stale
+// data" strategy used by apiserver's storage.GuaranteedUpdate: it calls
+// apply, and if apply fails because the cached object's ResourceVersion
+// went stale (apierrors.IsConflict), it calls refetch to bring the object
+// back up to date and retries apply, backing off exponentially, up to
+// maxRetries times. Any other error, or a conflict on the final attempt,
+// is returned as-is.
+func RetryOnConflict(
+	ctx context.Context, log logr.Logger, maxRetries int, refetch func() error, apply func() error,
+) error {
+	backoff := retryOnConflictBaseBackoff
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = apply()
+		if err == nil || !apierrors.IsConflict(err) {
+			return err
+		}
+		if attempt == maxRetries {
+			break
+		}
+
+		log.Info("conflict on stale resource version, retrying phase", "attempt", attempt+1)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		if refetchErr := refetch(); refetchErr != nil {
+			return refetchErr
+		}
+	}
+
+	return err
+}
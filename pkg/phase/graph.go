@@ -0,0 +1,329 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+package phase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k8s-connectors/pkg/config"
+)
+
+// Graph is a fixed set of phases together with the dependency edges implied
+// by matching each phase's DependsOn against every phase's Provides.
+type Graph struct {
+	phases   []Phase
+	byName   map[string]Phase
+	provider map[string]string // provided name -> name of the phase providing it
+	layers   [][]string        // phase names, in dependency order, grouped by what can run concurrently
+}
+
+// NewGraph builds a Graph from phases, failing fast on duplicate names,
+// dependencies that nothing provides, or a dependency cycle, so a
+// misconfigured graph is caught here rather than partway through Update.
+func NewGraph(phases []Phase) (*Graph, error) {
+	byName := make(map[string]Phase, len(phases))
+	provider := make(map[string]string, len(phases))
+	for _, p := range phases {
+		if _, exists := byName[p.Name()]; exists {
+			return nil, fmt.Errorf("duplicate phase name %q", p.Name())
+		}
+		byName[p.Name()] = p
+		for _, provided := range p.Provides() {
+			provider[provided] = p.Name()
+		}
+	}
+
+	g := &Graph{phases: phases, byName: byName, provider: provider}
+	layers, err := g.computeLayers()
+	if err != nil {
+		return nil, err
+	}
+	g.layers = layers
+	return g, nil
+}
+
+// computeLayers returns phase names grouped into layers: every phase in
+// layer i depends only on phases in layers 0..i-1, so all phases within a
+// single layer can be run concurrently.
+func (g *Graph) computeLayers() ([][]string, error) {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(g.phases))
+	depth := make(map[string]int, len(g.phases))
+
+	var visit func(name string) (int, error)
+	visit = func(name string) (int, error) {
+		switch state[name] {
+		case done:
+			return depth[name], nil
+		case inProgress:
+			return 0, fmt.Errorf("dependency cycle detected at phase %q", name)
+		}
+		state[name] = inProgress
+
+		p, ok := g.byName[name]
+		if !ok {
+			return 0, fmt.Errorf("phase %q not found in graph", name)
+		}
+
+		maxDepDepth := -1
+		for _, dep := range p.DependsOn() {
+			providerName, ok := g.provider[dep]
+			if !ok {
+				return 0, fmt.Errorf("phase %q depends on %q, which no phase provides", name, dep)
+			}
+			depDepth, err := visit(providerName)
+			if err != nil {
+				return 0, err
+			}
+			if depDepth > maxDepDepth {
+				maxDepDepth = depDepth
+			}
+		}
+
+		depth[name] = maxDepDepth + 1
+		state[name] = done
+		return depth[name], nil
+	}
+
+	var layers [][]string
+	for _, p := range g.phases {
+		d, err := visit(p.Name())
+		if err != nil {
+			return nil, err
+		}
+		for len(layers) <= d {
+			layers = append(layers, nil)
+		}
+		layers[d] = append(layers[d], p.Name())
+	}
+	return layers, nil
+}
+
+// Update runs IsUpdated/Update for every phase not yet updated, in
+// topological order, running the phases of a single layer concurrently. If
+// a phase fails, anything depending on it is skipped rather than run
+// against state it assumed was already there.
+//
+// obj is shared across an entire layer's goroutines, so every phase is
+// handed its own obj.DeepCopyObject() to work on and the result is folded
+// back under mu, rather than letting concurrent phases read and write the
+// same object. If a phase's Update conflicts on a stale ResourceVersion,
+// only that phase is retried, via refetch/maxRetries (see
+// config.RetryOnConflict) - refetch itself also runs under mu, since it
+// typically re-Gets obj in place.
+func (g *Graph) Update(
+	ctx context.Context, log logr.Logger, obj runtime.Object, refetch func() error, maxRetries int,
+) error {
+	failed := make(map[string]error)
+	var mu sync.Mutex
+
+	for _, layer := range g.layers {
+		eg, egCtx := errgroup.WithContext(ctx)
+		for _, name := range layer {
+			name := name
+			p := g.byName[name]
+
+			mu.Lock()
+			blockingDep := g.firstFailedDependency(p, failed)
+			mu.Unlock()
+			if blockingDep != "" {
+				mu.Lock()
+				failed[name] = fmt.Errorf("phase %q failed, skipping %q which depends on it", blockingDep, name)
+				mu.Unlock()
+				continue
+			}
+
+			eg.Go(func() error {
+				err := config.RetryOnConflict(egCtx, log, maxRetries, func() error {
+					mu.Lock()
+					defer mu.Unlock()
+					return refetch()
+				}, func() error {
+					mu.Lock()
+					localObj := obj.DeepCopyObject()
+					mu.Unlock()
+					beforeObj := localObj.DeepCopyObject()
+
+					if err := runPhaseUpdate(egCtx, log, localObj, p); err != nil {
+						return err
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					return mergeObject(obj, beforeObj, localObj)
+				})
+				if err != nil {
+					mu.Lock()
+					failed[name] = err
+					mu.Unlock()
+				}
+				return nil
+			})
+		}
+		_ = eg.Wait() // errors are collected into failed, not returned, so sibling phases still get a chance to run
+	}
+
+	return firstError(g.phases, failed)
+}
+
+// mergeObject folds onto dst only the fields a phase actually changed
+// between before (its own deep copy of the shared object, taken right
+// before it ran) and after (the same copy, once the phase is done with
+// it) - not the whole of after. A sibling phase running concurrently in
+// the same layer may have already merged its own, different fields into
+// dst; overwriting dst wholesale with after would silently revert those,
+// since after was copied before the sibling's merge happened. Diffing
+// against before and only touching what changed keeps this phase's merge
+// scoped to the fields it actually owns.
+func mergeObject(dst, before, after runtime.Object) error {
+	beforeMap, err := toMap(before)
+	if err != nil {
+		return err
+	}
+	afterMap, err := toMap(after)
+	if err != nil {
+		return err
+	}
+	dstMap, err := toMap(dst)
+	if err != nil {
+		return err
+	}
+
+	applyFieldChanges(dstMap, beforeMap, afterMap)
+
+	merged, err := json.Marshal(dstMap)
+	if err != nil {
+		return err
+	}
+	// json.Unmarshal keeps struct fields and map keys that are absent from
+	// merged, so zero dst first or the keys removed above would survive.
+	if v := reflect.ValueOf(dst); v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+	return json.Unmarshal(merged, dst)
+}
+
+func toMap(obj runtime.Object) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// applyFieldChanges recursively copies onto dst every leaf that differs
+// between before and after - i.e. what the phase that produced after
+// actually wrote - leaving everything else in dst untouched.
+func applyFieldChanges(dst, before, after map[string]interface{}) {
+	for key, afterVal := range after {
+		beforeVal, existedBefore := before[key]
+
+		afterSub, afterIsMap := afterVal.(map[string]interface{})
+		beforeSub, beforeIsMap := beforeVal.(map[string]interface{})
+		if afterIsMap {
+			dstSub, ok := dst[key].(map[string]interface{})
+			if !ok {
+				dstSub = map[string]interface{}{}
+			}
+			if !beforeIsMap {
+				beforeSub = map[string]interface{}{}
+			}
+			applyFieldChanges(dstSub, beforeSub, afterSub)
+			dst[key] = dstSub
+			continue
+		}
+
+		if !existedBefore || !reflect.DeepEqual(beforeVal, afterVal) {
+			dst[key] = afterVal
+		}
+	}
+	for key := range before {
+		if _, stillPresent := after[key]; !stillPresent {
+			delete(dst, key)
+		}
+	}
+}
+
+func runPhaseUpdate(ctx context.Context, log logr.Logger, obj runtime.Object, p Phase) error {
+	isUpdated, err := p.IsUpdated(ctx, obj)
+	if err != nil {
+		return err
+	}
+	if isUpdated {
+		return nil
+	}
+	return p.Update(ctx, log, obj)
+}
+
+// firstFailedDependency returns the name of the first already-failed phase
+// that p (transitively, through Provides/DependsOn) depends on, or "" if
+// none of p's dependencies have failed.
+func (g *Graph) firstFailedDependency(p Phase, failed map[string]error) string {
+	for _, dep := range p.DependsOn() {
+		providerName := g.provider[dep]
+		if _, ok := failed[providerName]; ok {
+			return providerName
+		}
+	}
+	return ""
+}
+
+// Cleanup runs Cleanup for every phase in reverse-topological order, i.e.
+// the opposite order to Update, so a phase is always torn down before
+// whatever it depended on. As in Update, every phase in a layer runs
+// against its own obj.DeepCopyObject(), merged back under mu, rather than
+// sharing obj directly with its concurrent siblings.
+func (g *Graph) Cleanup(ctx context.Context, log logr.Logger, obj runtime.Object) error {
+	var mu sync.Mutex
+	for i := len(g.layers) - 1; i >= 0; i-- {
+		layer := g.layers[i]
+		eg, egCtx := errgroup.WithContext(ctx)
+		for _, name := range layer {
+			p := g.byName[name]
+			eg.Go(func() error {
+				mu.Lock()
+				localObj := obj.DeepCopyObject()
+				mu.Unlock()
+				beforeObj := localObj.DeepCopyObject()
+
+				if err := p.Cleanup(egCtx, log, localObj); err != nil {
+					return err
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				return mergeObject(obj, beforeObj, localObj)
+			})
+		}
+		if err := eg.Wait(); err != nil {
+			return fmt.Errorf("error during finalization: %v", err)
+		}
+	}
+	return nil
+}
+
+func firstError(phases []Phase, failed map[string]error) error {
+	for _, p := range phases {
+		if err, ok := failed[p.Name()]; ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+// Package phase provides a generic, dependency-aware phase subsystem shared
+// by every connector's reconciler. A connector no longer hard-codes a slice
+// whose order encodes an implicit dependency between its phases; instead,
+// each phase declares what it needs and what it provides, and Graph works
+// out a safe order to run them in, including which independent phases can
+// run concurrently.
+package phase
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Phase is a single unit of reconciliation for some object. Name identifies
+// it within its connector's graph; DependsOn names the things it needs to
+// already be in place (matched against other phases' Provides, not phase
+// names directly, so a phase can be swapped out without touching its
+// dependents); Provides names what becomes available once this phase is
+// updated.
+type Phase interface {
+	Name() string
+	DependsOn() []string
+	Provides() []string
+
+	// IsUpdated reports whether obj already reflects this phase, i.e.
+	// whether Update is a no-op.
+	IsUpdated(ctx context.Context, obj runtime.Object) (bool, error)
+	// Update brings obj in line with this phase.
+	Update(ctx context.Context, log logr.Logger, obj runtime.Object) error
+	// Cleanup reverts whatever this phase set up.
+	Cleanup(ctx context.Context, log logr.Logger, obj runtime.Object) error
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Mutator is implemented by connectors that need to default or otherwise
+// mutate an object before it is persisted. Default is expected to mutate
+// obj in place; the handler diffs the result against the original to
+// build the JSON patch returned to the API server.
+type Mutator interface {
+	Default(ctx context.Context, log logr.Logger, obj runtime.Object) error
+}
+
+type mutatingHandler struct {
+	object  runtime.Object
+	decoder *admission.Decoder
+	log     logr.Logger
+	mutator Mutator
+}
+
+func NewMutatingHandler(m Mutator) admission.Handler {
+	return &mutatingHandler{
+		log:     logr.Discard(),
+		mutator: m,
+	}
+}
+
+func (r *mutatingHandler) InjectObject(obj runtime.Object) error {
+	r.object = obj
+	return nil
+}
+
+func (r *mutatingHandler) InjectDecoder(decoder *admission.Decoder) error {
+	r.decoder = decoder
+	return nil
+}
+
+func (r *mutatingHandler) InjectLogger(log logr.Logger) error {
+	r.log = log
+	return nil
+}
+
+func (r *mutatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response { //nolint:gocritic
+	// GoCritic warns about `hugeParam` req, but it is an interface that we are obliged to follow
+	obj := r.object.DeepCopyObject()
+
+	// Only creation and update can be defaulted; deletion has no object to mutate
+	switch req.Operation { //nolint:exhaustive
+	case v1.Create, v1.Update:
+		if err := r.decoder.Decode(req, obj); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	default:
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("invalid request operation: %s", req.Operation))
+	}
+
+	// Default may report non-fatal issues (e.g. a value it defaulted on the
+	// caller's behalf) as a warning-only ValidationError; those should not
+	// stop the patch from being applied, only be surfaced to the caller.
+	var warnings []string
+	if err := r.mutator.Default(ctx, r.log, obj); err != nil {
+		var valErr ValidationError
+		if !errors.As(err, &valErr) || valErr.HasErrors() {
+			return handleValidationError(err)
+		}
+		for _, f := range valErr.Fields {
+			warnings = append(warnings, f.Error())
+		}
+	}
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	resp := admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+	resp.Warnings = warnings
+	return resp
+}
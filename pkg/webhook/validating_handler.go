@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -74,13 +75,46 @@ func (r *validatingHandler) Handle(ctx context.Context, req admission.Request) a
 	}
 }
 
+// handleValidationError turns err into an admission.Response. A
+// ValidationError is translated field by field: entries with SeverityError
+// populate .Result.Details.Causes and deny the request, while
+// SeverityWarning entries are surfaced as response.Warnings so
+// `kubectl apply` shows them inline without denying anything. Any other
+// error is treated as unexpected and reported as a 500.
 func handleValidationError(err error) admission.Response {
-	if err != nil {
-		if errors.Is(err, ValidationError{}) {
-			return admission.Denied(err.Error())
-		}
+	if err == nil {
+		return admission.Allowed("")
+	}
+
+	var valErr ValidationError
+	if !errors.As(err, &valErr) {
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
-	return admission.Allowed("")
+	var causes []metav1.StatusCause
+	var warnings []string
+	denied := false
+	for _, f := range valErr.Fields {
+		if f.Severity != SeverityError {
+			warnings = append(warnings, f.Error())
+			continue
+		}
+		denied = true
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: f.Detail,
+			Field:   f.Field,
+		})
+	}
+
+	if !denied {
+		resp := admission.Allowed("")
+		resp.Warnings = warnings
+		return resp
+	}
+
+	resp := admission.Denied(valErr.Error())
+	resp.Result.Details = &metav1.StatusDetails{Causes: causes}
+	resp.Warnings = warnings
+	return resp
 }
@@ -0,0 +1,102 @@
+// Copyright (c) 2021 Yandex LLC. All rights reserved.
+// Author: Martynov Pavel <covariance@yandex-team.ru>
+
+package webhook
+
+import "fmt"
+
+// Severity distinguishes a FieldError that must deny the admission request
+// from one that should only be surfaced as a warning.
+type Severity string
+
+const (
+	SeverityError   Severity = "Error"
+	SeverityWarning Severity = "Warning"
+)
+
+// FieldError is a single validation failure tied to a field path on the
+// object under validation, in the spirit of apimachinery's field.Error,
+// kept local to this package so validators do not need to import it just
+// to report where a failure came from.
+type FieldError struct {
+	// Field is the path to the offending field, e.g. "spec.serviceAccountID".
+	// Empty if the error does not originate from a single field.
+	Field string
+	// BadValue is the value that was rejected, for inclusion in the message.
+	BadValue interface{}
+	// Detail explains why the value was rejected.
+	Detail string
+	// Severity controls whether this entry denies the request or is only
+	// reported as a warning.
+	Severity Severity
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Detail
+	}
+	return fmt.Sprintf("%s: %s (was %v)", e.Field, e.Detail, e.BadValue)
+}
+
+// ValidationError aggregates the FieldErrors found while validating a
+// single admission request. handleValidationError denies the request if
+// any entry has SeverityError, and surfaces SeverityWarning entries via
+// admission.Response.Warnings without denying anything.
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+func (e ValidationError) Error() string {
+	if len(e.Fields) == 1 {
+		return e.Fields[0].Error()
+	}
+	msg := fmt.Sprintf("%d validation errors occurred:", len(e.Fields))
+	for _, f := range e.Fields {
+		msg += "\n  " + f.Error()
+	}
+	return msg
+}
+
+// Is reports any ValidationError as equal, regardless of its Fields, so
+// callers can keep using errors.Is(err, ValidationError{}) to distinguish
+// a validation failure from an unexpected error.
+func (e ValidationError) Is(target error) bool {
+	_, ok := target.(ValidationError)
+	return ok
+}
+
+// HasErrors reports whether any field in e has SeverityError, i.e. whether
+// admitting the request should be denied. A ValidationError built entirely
+// from SeverityWarning entries does not deny anything on its own.
+func (e ValidationError) HasErrors() bool {
+	for _, f := range e.Fields {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// NewValidationError wraps a plain error with no specific field path into
+// a ValidationError that denies the request.
+func NewValidationError(err error) ValidationError {
+	return ValidationError{
+		Fields: []*FieldError{{Detail: err.Error(), Severity: SeverityError}},
+	}
+}
+
+// NewFieldValidationError builds a ValidationError for a single field,
+// denying the request.
+func NewFieldValidationError(field string, badValue interface{}, detail string) ValidationError {
+	return ValidationError{
+		Fields: []*FieldError{{Field: field, BadValue: badValue, Detail: detail, Severity: SeverityError}},
+	}
+}
+
+// NewFieldValidationWarning builds a ValidationError for a single field
+// that should not deny the request, only warn the caller about it.
+func NewFieldValidationWarning(field string, badValue interface{}, detail string) ValidationError {
+	return ValidationError{
+		Fields: []*FieldError{{Field: field, BadValue: badValue, Detail: detail, Severity: SeverityWarning}},
+	}
+}